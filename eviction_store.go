@@ -1,82 +1,519 @@
 package eviction_store
 
 import (
+	"container/heap"
 	"container/list"
+	"fmt"
+	"math/rand"
 	"sync"
 	"time"
 
+	"golang.org/x/sync/singleflight"
 	"k8s.io/apimachinery/pkg/util/clock"
 )
 
-type keyFunction func(obj interface{}) string
+type keyFunction[K comparable, V any] func(obj V) K
 
-type item struct {
-	obj       interface{}
+type item[K comparable, V any] struct {
+	obj       V
 	timestamp time.Time
+	ttl       time.Duration
+	// effectiveTTL is ttl after WithExpiryJitter has perturbed it. It is
+	// computed once, at insertion time, and reused by every subsequent
+	// sliding-expiration refresh so an item's jitter doesn't drift on touch.
+	effectiveTTL time.Duration
+	heapItem     *heapItem[K]
 }
 
-type evictionStore struct {
-	store            map[string]*list.Element
-	queue            *list.List
-	lock             sync.Mutex
-	keyFunc          keyFunction
-	ttl              time.Duration
-	lastEvictionTime time.Time
-	clock            clock.Clock
+// ExpirationPolicy controls whether Get extends an item's deadline.
+type ExpirationPolicy int
+
+const (
+	// SlidingExpiration extends an item's deadline by its TTL every time it
+	// is touched via Get. This is the default.
+	SlidingExpiration ExpirationPolicy = iota
+	// AbsoluteExpiration leaves an item's deadline exactly as it was set by
+	// Add/AddWithTTL; Get never extends it. Useful for caching values whose
+	// lifetime is dictated by an external issuer, such as tokens.
+	AbsoluteExpiration
+)
+
+// heapItem tracks an item's expiration deadline on the expirations heap.
+// index is maintained by container/heap and lets Add, Get, Delete and
+// evictCapacityLocked fix up or remove an entry in O(log n) instead of
+// scanning the heap.
+type heapItem[K comparable] struct {
+	key      K
+	deadline time.Time
+	index    int
+}
+
+// expirationHeap is a min-heap of heapItems ordered by deadline, so the
+// janitor can always find the next item to expire in O(1) and pop it in
+// O(log n).
+type expirationHeap[K comparable] []*heapItem[K]
+
+func (h expirationHeap[K]) Len() int { return len(h) }
+
+func (h expirationHeap[K]) Less(i, j int) bool { return h[i].deadline.Before(h[j].deadline) }
+
+func (h expirationHeap[K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expirationHeap[K]) Push(x interface{}) {
+	hi := x.(*heapItem[K])
+	hi.index = len(*h)
+	*h = append(*h, hi)
+}
+
+func (h *expirationHeap[K]) Pop() interface{} {
+	old := *h
+	n := len(old)
+	hi := old[n-1]
+	old[n-1] = nil
+	hi.index = -1
+	*h = old[:n-1]
+	return hi
+}
+
+// evictedItem carries the key/obj pair of an item removed from the store,
+// collected under the lock so that callbacks can be fired after it is released.
+type evictedItem[K comparable, V any] struct {
+	key K
+	obj V
+}
+
+// Src loads the value for a key missing from the store, turning it into a
+// read-through cache. See WithSource and GetOrLoad.
+type Src[K comparable, V any] func(key K) (V, error)
+
+// Stats reports cache performance counters accumulated since the store was
+// created.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Expired   uint64
+}
+
+// Store is a TTL-evicting cache mapping keys of type K to values of type V.
+type Store[K comparable, V any] struct {
+	store   map[K]*list.Element
+	queue   *list.List
+	lock    sync.Mutex
+	keyFunc keyFunction[K, V]
+	ttl     time.Duration
+	clock   clock.Clock
+
+	expirationPolicy ExpirationPolicy
+
+	// expirations is a min-heap keyed by deadline that the janitor uses to
+	// sleep until the next item is due to expire. queue stays in LRU touch
+	// order for Len/List/capacity eviction purposes.
+	expirations expirationHeap[K]
+	// timer is set while Run is active, so that Add can wake the janitor
+	// early when it inserts an item that becomes the new earliest deadline.
+	timer clock.Timer
+
+	onInsertion []func(key K, obj V)
+	onEviction  []func(key K, obj V)
+
+	// capacity bounds the number of items the store holds; 0 means unbounded.
+	capacity uint64
+	// src, when set, turns the store into a read-through cache: GetOrLoad
+	// calls it on a miss and caches the result. group collapses concurrent
+	// misses for the same key into a single call.
+	src   Src[K, V]
+	group singleflight.Group
+
+	stats Stats
+
+	// expiryJitter perturbs each item's effective TTL by a uniform random
+	// factor in [1-expiryJitter, 1+expiryJitter] at insertion time, to avoid
+	// synchronized eviction storms when many items are inserted in a burst.
+	expiryJitter float64
+}
+
+// Option configures a Store at construction time.
+type Option[K comparable, V any] func(*Store[K, V])
+
+// WithExpirationPolicy selects whether Get extends an item's deadline
+// (SlidingExpiration, the default) or leaves it untouched (AbsoluteExpiration).
+func WithExpirationPolicy[K comparable, V any](policy ExpirationPolicy) Option[K, V] {
+	return func(s *Store[K, V]) {
+		s.expirationPolicy = policy
+	}
+}
+
+// WithCapacity bounds the store to at most n items. Once Add would exceed it,
+// the least recently touched item is evicted regardless of its TTL.
+func WithCapacity[K comparable, V any](n uint64) Option[K, V] {
+	return func(s *Store[K, V]) {
+		s.capacity = n
+	}
+}
+
+// WithSource configures a loader invoked by GetOrLoad on a cache miss, turning
+// the store into a read-through cache.
+func WithSource[K comparable, V any](src Src[K, V]) Option[K, V] {
+	return func(s *Store[K, V]) {
+		s.src = src
+	}
+}
+
+// WithExpiryJitter perturbs each item's effective TTL by a uniform random
+// factor in [1-fraction, 1+fraction] at insertion time (e.g. 0.05 means
+// ±5%), so a burst of inserts sharing the same TTL doesn't all expire in the
+// same instant and cause a spike of evictions and downstream reloads.
+func WithExpiryJitter[K comparable, V any](fraction float64) Option[K, V] {
+	return func(s *Store[K, V]) {
+		s.expiryJitter = fraction
+	}
 }
 
-func New(keyFunc keyFunction, ttl time.Duration, clock clock.Clock) *evictionStore {
-	return &evictionStore{
+func New[K comparable, V any](keyFunc keyFunction[K, V], ttl time.Duration, clock clock.Clock, opts ...Option[K, V]) *Store[K, V] {
+	s := &Store[K, V]{
 		keyFunc: keyFunc,
-		store:   map[string]*list.Element{},
+		store:   map[K]*list.Element{},
 		queue:   list.New(),
 		ttl:     ttl,
 		clock:   clock,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *evictionStore) Add(obj interface{}) {
-	ts := s.clock.Now()
+// OnInsertion registers a callback that is invoked whenever a new object is
+// added to the store. Callbacks are invoked outside of the store's lock, so
+// they may safely call back into the store (e.g. Add, Get, Delete).
+func (s *Store[K, V]) OnInsertion(fn func(key K, obj V)) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
-	defer s.evictLocked(ts)
+	s.onInsertion = append(s.onInsertion, fn)
+}
 
+// OnEviction registers a callback that is invoked whenever an object leaves
+// the store, whether because its TTL expired, because it was evicted to stay
+// within capacity, or because it was explicitly deleted. Callbacks are invoked outside of the store's lock, so they may
+// safely call back into the store.
+func (s *Store[K, V]) OnEviction(fn func(key K, obj V)) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.onEviction = append(s.onEviction, fn)
+}
+
+// Run starts a background janitor that proactively evicts items as their TTL
+// elapses, instead of relying on Add/Get to trigger a sweep. It blocks until
+// stopCh is closed, so callers typically invoke it in its own goroutine.
+func (s *Store[K, V]) Run(stopCh <-chan struct{}) {
+	s.lock.Lock()
+	delay, ok := s.nextDeadlineLocked()
+	if !ok {
+		delay = s.ttl
+	}
+	timer := s.clock.NewTimer(delay)
+	s.timer = timer
+	s.lock.Unlock()
+
+	defer func() {
+		timer.Stop()
+		s.lock.Lock()
+		s.timer = nil
+		s.lock.Unlock()
+	}()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-timer.C():
+			s.lock.Lock()
+			evicted := s.evictLocked(s.clock.Now())
+			s.stats.Expired += uint64(len(evicted))
+			delay, ok := s.nextDeadlineLocked()
+			if !ok {
+				delay = s.ttl
+			}
+			s.lock.Unlock()
+
+			s.fireEvictions(evicted)
+			timer.Reset(delay)
+		}
+	}
+}
+
+// Add inserts obj using the store's default TTL. See AddWithTTL.
+func (s *Store[K, V]) Add(obj V) {
+	s.AddWithTTL(obj, s.ttl)
+}
+
+// AddWithTTL inserts obj with a per-item TTL, overriding the store's default
+// for this item. Re-adding an existing key refreshes both its timestamp and
+// its TTL.
+func (s *Store[K, V]) AddWithTTL(obj V, ttl time.Duration) {
+	ts := s.clock.Now()
 	key := s.keyFunc(obj)
+
+	s.lock.Lock()
+	effectiveTTL := s.jitteredTTL(ttl)
+	inserted := false
+	becameEarliest := false
 	if e, ok := s.store[key]; ok {
-		e.Value.(*item).timestamp = ts
+		it := e.Value.(*item[K, V])
+		it.obj = obj
+		it.timestamp = ts
+		it.ttl = ttl
+		it.effectiveTTL = effectiveTTL
 		s.queue.MoveToFront(e)
-		return
+		it.heapItem.deadline = ts.Add(effectiveTTL)
+		heap.Fix(&s.expirations, it.heapItem.index)
+		becameEarliest = it.heapItem.index == 0
+	} else {
+		hi := &heapItem[K]{key: key, deadline: ts.Add(effectiveTTL)}
+		heap.Push(&s.expirations, hi)
+		s.store[key] = s.queue.PushFront(&item[K, V]{obj: obj, timestamp: ts, ttl: ttl, effectiveTTL: effectiveTTL, heapItem: hi})
+		inserted = true
+		becameEarliest = hi.index == 0
+	}
+	expired := s.evictLocked(ts)
+	s.stats.Expired += uint64(len(expired))
+	overCapacity := s.evictCapacityLocked()
+	s.stats.Evictions += uint64(len(overCapacity))
+	evicted := append(expired, overCapacity...)
+
+	if becameEarliest || len(overCapacity) > 0 {
+		s.resetTimerLocked()
+	}
+	s.lock.Unlock()
+
+	s.fireEvictions(evicted)
+	if inserted {
+		s.fireInsertion(key, obj)
 	}
-	s.store[key] = s.queue.PushFront(&item{obj: obj, timestamp: ts})
 }
 
-func (s *evictionStore) Get(key string) interface{} {
+// Get returns the value stored under key and reports whether it was found.
+func (s *Store[K, V]) Get(key K) (V, bool) {
 	ts := s.clock.Now()
-	defer s.evictLocked(ts)
 
+	s.lock.Lock()
+	var obj V
+	found := false
 	if e, ok := s.store[key]; ok {
-		e.Value.(*item).timestamp = ts
+		it := e.Value.(*item[K, V])
 		s.queue.MoveToFront(e)
-		return e.Value.(*item).obj
+		obj = it.obj
+		found = true
+		if s.expirationPolicy == SlidingExpiration {
+			it.timestamp = ts
+			it.heapItem.deadline = ts.Add(it.effectiveTTL)
+			heap.Fix(&s.expirations, it.heapItem.index)
+		}
+		s.stats.Hits++
+	} else {
+		s.stats.Misses++
 	}
+	expired := s.evictLocked(ts)
+	s.stats.Expired += uint64(len(expired))
+	s.lock.Unlock()
 
-	return nil
+	s.fireEvictions(expired)
+	return obj, found
 }
 
-func (s *evictionStore) evictLocked(timestamp time.Time) {
-	if s.lastEvictionTime.Add(s.ttl).After(timestamp) {
+// GetOrLoad returns the cached value for key, invoking the loader configured
+// via WithSource on a miss and caching its result. Concurrent misses for the
+// same key share a single loader call. If no loader is configured, it behaves
+// like Get and never returns an error.
+func (s *Store[K, V]) GetOrLoad(key K) (V, error) {
+	if obj, ok := s.Get(key); ok {
+		return obj, nil
+	}
+	if s.src == nil {
+		var zero V
+		return zero, nil
+	}
+
+	obj, err, _ := s.group.Do(fmt.Sprint(key), func() (interface{}, error) {
+		obj, err := s.src(key)
+		if err != nil {
+			return nil, err
+		}
+		s.Add(obj)
+		return obj, nil
+	})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return obj.(V), nil
+}
+
+// Delete removes the object stored under key, if any, and fires the eviction
+// callbacks registered via OnEviction.
+func (s *Store[K, V]) Delete(key K) {
+	s.lock.Lock()
+	e, ok := s.store[key]
+	if !ok {
+		s.lock.Unlock()
 		return
 	}
-	for {
-		if s.queue.Len() == 0 {
+	it := e.Value.(*item[K, V])
+	delete(s.store, key)
+	s.queue.Remove(e)
+	heap.Remove(&s.expirations, it.heapItem.index)
+	s.lock.Unlock()
+
+	s.fireEvictions([]evictedItem[K, V]{{key: key, obj: it.obj}})
+}
+
+// Len returns the number of objects currently held by the store.
+func (s *Store[K, V]) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.queue.Len()
+}
+
+// Keys returns the keys of all objects currently held by the store, in no
+// particular order.
+func (s *Store[K, V]) Keys() []K {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	keys := make([]K, 0, len(s.store))
+	for key := range s.store {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// List returns all objects currently held by the store, ordered from most
+// to least recently touched.
+func (s *Store[K, V]) List() []V {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	objs := make([]V, 0, s.queue.Len())
+	for e := s.queue.Front(); e != nil; e = e.Next() {
+		objs = append(objs, e.Value.(*item[K, V]).obj)
+	}
+	return objs
+}
+
+// evictLocked pops every item whose deadline has passed off the expirations
+// heap and returns them so the caller can fire eviction callbacks once the
+// lock is released. s.lock must be held by the caller.
+func (s *Store[K, V]) evictLocked(timestamp time.Time) []evictedItem[K, V] {
+	var evicted []evictedItem[K, V]
+	for s.expirations.Len() > 0 {
+		hi := s.expirations[0]
+		if hi.deadline.After(timestamp) {
 			break
 		}
+		heap.Pop(&s.expirations)
+		e := s.store[hi.key]
+		it := e.Value.(*item[K, V])
+		delete(s.store, hi.key)
+		s.queue.Remove(e)
+		evicted = append(evicted, evictedItem[K, V]{key: hi.key, obj: it.obj})
+	}
+	return evicted
+}
+
+// evictCapacityLocked evicts items from the back of the LRU queue, regardless
+// of their TTL, until the store is at or under capacity. s.lock must be held
+// by the caller.
+func (s *Store[K, V]) evictCapacityLocked() []evictedItem[K, V] {
+	if s.capacity == 0 {
+		return nil
+	}
+
+	var evicted []evictedItem[K, V]
+	for uint64(s.queue.Len()) > s.capacity {
 		e := s.queue.Back()
-		if e.Value.(*item).timestamp.Add(s.ttl).After(timestamp) {
-			break
-		}
-		delete(s.store, s.keyFunc(e.Value.(*item).obj))
+		it := e.Value.(*item[K, V])
+		key := s.keyFunc(it.obj)
+		delete(s.store, key)
 		s.queue.Remove(e)
+		heap.Remove(&s.expirations, it.heapItem.index)
+		evicted = append(evicted, evictedItem[K, V]{key: key, obj: it.obj})
+	}
+	return evicted
+}
+
+// jitteredTTL perturbs ttl by a uniform random factor in
+// [1-expiryJitter, 1+expiryJitter], or returns it unchanged if no jitter is
+// configured.
+func (s *Store[K, V]) jitteredTTL(ttl time.Duration) time.Duration {
+	if s.expiryJitter <= 0 {
+		return ttl
+	}
+	factor := 1 - s.expiryJitter + rand.Float64()*2*s.expiryJitter
+	return time.Duration(float64(ttl) * factor)
+}
+
+// Stats returns a snapshot of the store's cache performance counters.
+func (s *Store[K, V]) Stats() Stats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.stats
+}
+
+// nextDeadlineLocked returns how long the janitor should sleep before the
+// next item is due to expire. s.lock must be held by the caller.
+func (s *Store[K, V]) nextDeadlineLocked() (time.Duration, bool) {
+	if s.expirations.Len() == 0 {
+		return 0, false
+	}
+	delay := s.expirations[0].deadline.Sub(s.clock.Now())
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+// resetTimerLocked wakes the janitor's timer early so it picks up a new
+// earliest deadline. It is a no-op if Run isn't active. s.lock must be held
+// by the caller.
+func (s *Store[K, V]) resetTimerLocked() {
+	if s.timer == nil {
+		return
+	}
+	delay, ok := s.nextDeadlineLocked()
+	if !ok {
+		return
+	}
+	s.timer.Reset(delay)
+}
+
+// fireEvictions invokes the registered eviction callbacks for each evicted
+// item. It must be called without s.lock held.
+func (s *Store[K, V]) fireEvictions(evicted []evictedItem[K, V]) {
+	if len(evicted) == 0 {
+		return
+	}
+	s.lock.Lock()
+	callbacks := s.onEviction
+	s.lock.Unlock()
+
+	for _, ev := range evicted {
+		for _, cb := range callbacks {
+			cb(ev.key, ev.obj)
+		}
+	}
+}
+
+// fireInsertion invokes the registered insertion callbacks for a single
+// object. It must be called without s.lock held.
+func (s *Store[K, V]) fireInsertion(key K, obj V) {
+	s.lock.Lock()
+	callbacks := s.onInsertion
+	s.lock.Unlock()
+
+	for _, cb := range callbacks {
+		cb(key, obj)
 	}
-	s.lastEvictionTime = timestamp
 }
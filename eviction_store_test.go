@@ -1,7 +1,8 @@
 package eviction_store
 
 import (
-	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -13,16 +14,16 @@ type itemTest struct {
 	key string
 }
 
+func testKeyFunc(obj *itemTest) string {
+	return obj.key
+}
+
 func TestEvictionStore(t *testing.T) {
 	type step struct {
 		clockStep time.Duration
 		keysToGet sets.String
 	}
 
-	defaultKeyFunc := func(obj interface{}) string {
-		return obj.(*itemTest).key
-	}
-
 	scenarios := []struct {
 		name         string
 		objs         []*itemTest
@@ -129,7 +130,7 @@ func TestEvictionStore(t *testing.T) {
 			fakeClock := &clock.FakeClock{}
 
 			// act
-			target := New(defaultKeyFunc, 10*time.Minute, fakeClock)
+			target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock)
 			for _, obj := range scenario.objs {
 				target.Add(obj)
 			}
@@ -144,11 +145,10 @@ func TestEvictionStore(t *testing.T) {
 			// validate
 			expectedObjs := scenario.expectedObjs(scenario.objs)
 			for _, obj := range scenario.objs {
-				actualObj := target.Get(defaultKeyFunc(obj))
+				actualObj, _ := target.Get(testKeyFunc(obj))
 				found := false
 				for _, expectedObj := range expectedObjs {
-					if actualObj.(*itemTest) == expectedObj {
-						fmt.Printf("actual %p, expected = %p\n", actualObj.(*itemTest), expectedObj)
+					if actualObj == expectedObj {
 						found = true
 						break
 					}
@@ -156,16 +156,273 @@ func TestEvictionStore(t *testing.T) {
 				if found {
 					return
 				}
-				okToMiss := shouldMiss(scenario.objs, expectedObjs, defaultKeyFunc(obj), defaultKeyFunc)
+				okToMiss := shouldMiss(scenario.objs, expectedObjs, testKeyFunc(obj), testKeyFunc)
 				if !found && okToMiss {
 					return
 				}
-				t.Fatalf("an object with key %s not found", defaultKeyFunc(obj))
+				t.Fatalf("an object with key %s not found", testKeyFunc(obj))
 			}
 		})
 	}
 }
 
+func TestEvictionStoreDeleteLenKeysList(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock)
+	target.Add(&itemTest{key: "1"})
+	target.Add(&itemTest{key: "2"})
+	target.Add(&itemTest{key: "3"})
+
+	if actual := target.Len(); actual != 3 {
+		t.Fatalf("expected Len() to return 3, got %d", actual)
+	}
+	if actual := sets.NewString(target.Keys()...); !actual.Equal(sets.NewString("1", "2", "3")) {
+		t.Fatalf("unexpected keys: %v", actual.List())
+	}
+	if actual := len(target.List()); actual != 3 {
+		t.Fatalf("expected List() to return 3 objects, got %d", actual)
+	}
+
+	target.Delete("2")
+
+	if actual := target.Len(); actual != 2 {
+		t.Fatalf("expected Len() to return 2 after Delete, got %d", actual)
+	}
+	if actual := sets.NewString(target.Keys()...); !actual.Equal(sets.NewString("1", "3")) {
+		t.Fatalf("unexpected keys after Delete: %v", actual.List())
+	}
+	if _, ok := target.Get("2"); ok {
+		t.Fatalf("expected deleted key to be gone")
+	}
+
+	// deleting a key that isn't present is a no-op
+	target.Delete("2")
+	if actual := target.Len(); actual != 2 {
+		t.Fatalf("expected Len() to stay at 2 after deleting a missing key, got %d", actual)
+	}
+}
+
+func TestEvictionStoreCallbacks(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock)
+
+	var insertedKeys []string
+	target.OnInsertion(func(key string, obj *itemTest) {
+		insertedKeys = append(insertedKeys, key)
+	})
+
+	var evictedKeys []string
+	target.OnEviction(func(key string, obj *itemTest) {
+		// a callback must be able to call back into the store without deadlocking
+		target.Len()
+		evictedKeys = append(evictedKeys, key)
+	})
+
+	target.Add(&itemTest{key: "1"})
+	target.Add(&itemTest{key: "2"})
+	if actual := sets.NewString(insertedKeys...); !actual.Equal(sets.NewString("1", "2")) {
+		t.Fatalf("unexpected insertion callbacks: %v", insertedKeys)
+	}
+
+	target.Delete("1")
+	if actual := sets.NewString(evictedKeys...); !actual.Equal(sets.NewString("1")) {
+		t.Fatalf("expected Delete to fire an eviction callback for key 1, got %v", evictedKeys)
+	}
+
+	fakeClock.Step(11 * time.Minute)
+	target.Add(&itemTest{key: "3"})
+	if actual := sets.NewString(evictedKeys...); !actual.Equal(sets.NewString("1", "2")) {
+		t.Fatalf("expected TTL expiration to fire an eviction callback for key 2, got %v", evictedKeys)
+	}
+}
+
+func TestEvictionStoreJanitor(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock)
+
+	evictedCh := make(chan string, 1)
+	target.OnEviction(func(key string, obj *itemTest) {
+		evictedCh <- key
+	})
+
+	target.Add(&itemTest{key: "1"})
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go target.Run(stopCh)
+
+	// wait until the janitor has installed its timer before stepping the clock
+	for !fakeClock.HasWaiters() {
+		time.Sleep(time.Millisecond)
+	}
+	fakeClock.Step(11 * time.Minute)
+
+	select {
+	case key := <-evictedCh:
+		if key != "1" {
+			t.Fatalf("expected the janitor to evict key 1, got %s", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for the janitor to proactively evict an expired item")
+	}
+
+	if actual := target.Len(); actual != 0 {
+		t.Fatalf("expected the store to be empty after the janitor ran, got %d", actual)
+	}
+	if actual := target.Stats().Expired; actual != 1 {
+		t.Fatalf("expected Stats().Expired to report 1 janitor-driven expiry, got %d", actual)
+	}
+}
+
+func TestEvictionStoreStatsExpired(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	// use AbsoluteExpiration so that touching the item via Get does not push
+	// its deadline out; see TestEvictionStoreAbsoluteExpiration.
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock, WithExpirationPolicy[string, *itemTest](AbsoluteExpiration))
+
+	target.Add(&itemTest{key: "1"})
+	fakeClock.Step(11 * time.Minute)
+	target.Get("1")
+
+	if actual := target.Stats().Expired; actual != 1 {
+		t.Fatalf("expected Stats().Expired to report 1 Get-driven expiry, got %d", actual)
+	}
+}
+
+func TestEvictionStoreAbsoluteExpiration(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock, WithExpirationPolicy[string, *itemTest](AbsoluteExpiration))
+
+	target.Add(&itemTest{key: "1"})
+
+	fakeClock.Step(8 * time.Minute)
+	if _, ok := target.Get("1"); !ok {
+		t.Fatalf("expected key 1 to still be present after 8 minutes")
+	}
+
+	// under absolute expiration, touching the item via Get must not push its
+	// deadline out, so it still expires 10 minutes after it was added. The
+	// first Get past the deadline triggers the eviction sweep; the next one
+	// observes the item is actually gone.
+	fakeClock.Step(3 * time.Minute)
+	target.Get("1")
+	if _, ok := target.Get("1"); ok {
+		t.Fatalf("expected key 1 to have expired 10 minutes after it was added")
+	}
+}
+
+func TestEvictionStoreAddWithTTL(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock)
+
+	target.AddWithTTL(&itemTest{key: "short"}, 2*time.Minute)
+	target.Add(&itemTest{key: "long"})
+
+	fakeClock.Step(3 * time.Minute)
+	target.Get("long") // triggers the eviction sweep for the already-expired short-TTL key
+	if _, ok := target.Get("short"); ok {
+		t.Fatalf("expected the short-TTL key to have expired")
+	}
+	if _, ok := target.Get("long"); !ok {
+		t.Fatalf("expected the default-TTL key to still be present")
+	}
+}
+
+func TestEvictionStoreCapacityLRU(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock, WithCapacity[string, *itemTest](2))
+
+	target.Add(&itemTest{key: "1"})
+	target.Add(&itemTest{key: "2"})
+	target.Get("1") // touch key 1 so key 2 becomes the LRU tail
+	target.Add(&itemTest{key: "3"})
+
+	if actual := target.Len(); actual != 2 {
+		t.Fatalf("expected capacity to cap the store at 2 items, got %d", actual)
+	}
+	if actual := sets.NewString(target.Keys()...); !actual.Equal(sets.NewString("1", "3")) {
+		t.Fatalf("expected the LRU tail (key 2) to be evicted, got keys %v", actual.List())
+	}
+	if actual := target.Stats().Evictions; actual != 1 {
+		t.Fatalf("expected Stats().Evictions to report 1 capacity eviction, got %d", actual)
+	}
+}
+
+func TestEvictionStoreStatsHitsAndMisses(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock)
+
+	target.Add(&itemTest{key: "1"})
+	target.Get("1")
+	target.Get("missing")
+
+	stats := target.Stats()
+	if stats.Hits != 1 {
+		t.Fatalf("expected 1 hit, got %d", stats.Hits)
+	}
+	if stats.Misses != 1 {
+		t.Fatalf("expected 1 miss, got %d", stats.Misses)
+	}
+}
+
+func TestEvictionStoreGetOrLoad(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+
+	var loads int32
+	src := func(key string) (*itemTest, error) {
+		atomic.AddInt32(&loads, 1)
+		time.Sleep(10 * time.Millisecond)
+		return &itemTest{key: key}, nil
+	}
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock, WithSource[string, *itemTest](src))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obj, err := target.GetOrLoad("1")
+			if err != nil {
+				t.Errorf("unexpected error from GetOrLoad: %v", err)
+			}
+			if obj == nil {
+				t.Error("expected GetOrLoad to return a loaded object")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if actual := atomic.LoadInt32(&loads); actual != 1 {
+		t.Fatalf("expected concurrent misses to share a single loader call via singleflight, got %d calls", actual)
+	}
+	if actual := target.Len(); actual != 1 {
+		t.Fatalf("expected the loaded object to be cached, got %d items", actual)
+	}
+}
+
+func TestEvictionStoreExpiryJitter(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	// ttl=10min, jitter=0.5 => effective ttl is somewhere in [5min, 15min].
+	// Absolute expiration keeps that deadline from shifting on Get, so the
+	// bounds can be asserted deterministically regardless of the jitter draw.
+	target := New[string, *itemTest](testKeyFunc, 10*time.Minute, fakeClock,
+		WithExpiryJitter[string, *itemTest](0.5), WithExpirationPolicy[string, *itemTest](AbsoluteExpiration))
+
+	target.Add(&itemTest{key: "1"})
+
+	fakeClock.Step(4 * time.Minute)
+	if _, ok := target.Get("1"); !ok {
+		t.Fatalf("expected key 1 to still be present below the minimum jittered TTL of 5 minutes")
+	}
+
+	fakeClock.Step(12 * time.Minute) // total 16 minutes, past the maximum jittered TTL of 15 minutes
+	target.Get("1")
+	if _, ok := target.Get("1"); ok {
+		t.Fatalf("expected key 1 to have expired past the maximum jittered TTL")
+	}
+}
+
 func filterObjs(objs []*itemTest, interestingKeys sets.String) []*itemTest {
 	ret := []*itemTest{}
 	for _, obj := range objs {
@@ -176,7 +433,7 @@ func filterObjs(objs []*itemTest, interestingKeys sets.String) []*itemTest {
 	return ret
 }
 
-func shouldMiss(actual []*itemTest, expected []*itemTest, missingKey string, keyFunc func(obj interface{}) string) bool {
+func shouldMiss(actual []*itemTest, expected []*itemTest, missingKey string, keyFunc func(obj *itemTest) string) bool {
 	actualKeySet := sets.NewString()
 	for _, a := range actual {
 		actualKeySet.Insert(keyFunc(a))
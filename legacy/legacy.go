@@ -0,0 +1,127 @@
+// Package legacy provides a thin interface{}-based wrapper around
+// eviction_store.Store, preserving the API shape used before the store
+// became generic. New callers should prefer the generic store directly;
+// this package exists only to keep older call sites compiling unchanged.
+package legacy
+
+import (
+	"time"
+
+	store "github.com/p0lyn0mial/ttl-cache"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+// KeyFunction extracts the key under which an object is stored.
+type KeyFunction func(obj interface{}) string
+
+// Src loads the value for a key missing from the store. See WithSource.
+type Src func(key string) (interface{}, error)
+
+// Option configures an EvictionStore at construction time.
+type Option func(*store.Store[string, interface{}])
+
+// WithExpirationPolicy selects whether Get extends an item's deadline.
+func WithExpirationPolicy(policy store.ExpirationPolicy) Option {
+	return Option(store.WithExpirationPolicy[string, interface{}](policy))
+}
+
+// WithCapacity bounds the store to at most n items, evicting the least
+// recently touched item once it is exceeded.
+func WithCapacity(n uint64) Option {
+	return Option(store.WithCapacity[string, interface{}](n))
+}
+
+// WithSource configures a loader invoked by GetOrLoad on a cache miss.
+func WithSource(src Src) Option {
+	return Option(store.WithSource[string, interface{}](store.Src[string, interface{}](src)))
+}
+
+// WithExpiryJitter perturbs each item's effective TTL by a uniform random
+// factor in [1-fraction, 1+fraction] at insertion time.
+func WithExpiryJitter(fraction float64) Option {
+	return Option(store.WithExpiryJitter[string, interface{}](fraction))
+}
+
+// EvictionStore is an interface{}-based TTL-evicting cache, preserved for
+// callers that predate the generic Store[K, V].
+type EvictionStore struct {
+	delegate *store.Store[string, interface{}]
+}
+
+// New returns an EvictionStore wrapping a generic Store[string, interface{}].
+func New(keyFunc KeyFunction, ttl time.Duration, clock clock.Clock, opts ...Option) *EvictionStore {
+	genericOpts := make([]store.Option[string, interface{}], 0, len(opts))
+	for _, opt := range opts {
+		genericOpts = append(genericOpts, store.Option[string, interface{}](opt))
+	}
+	return &EvictionStore{
+		delegate: store.New[string, interface{}](func(obj interface{}) string { return keyFunc(obj) }, ttl, clock, genericOpts...),
+	}
+}
+
+// OnInsertion registers a callback invoked whenever a new object is added.
+func (s *EvictionStore) OnInsertion(fn func(key string, obj interface{})) {
+	s.delegate.OnInsertion(fn)
+}
+
+// OnEviction registers a callback invoked whenever an object leaves the
+// store, whether through TTL expiration, capacity eviction or explicit
+// deletion.
+func (s *EvictionStore) OnEviction(fn func(key string, obj interface{})) {
+	s.delegate.OnEviction(fn)
+}
+
+// Run starts a background janitor that proactively evicts expired items. It
+// blocks until stopCh is closed.
+func (s *EvictionStore) Run(stopCh <-chan struct{}) {
+	s.delegate.Run(stopCh)
+}
+
+// Add inserts obj using the store's default TTL.
+func (s *EvictionStore) Add(obj interface{}) {
+	s.delegate.Add(obj)
+}
+
+// AddWithTTL inserts obj with a per-item TTL, overriding the store's default.
+func (s *EvictionStore) AddWithTTL(obj interface{}, ttl time.Duration) {
+	s.delegate.AddWithTTL(obj, ttl)
+}
+
+// Get returns the object stored under key, or nil if it isn't present.
+func (s *EvictionStore) Get(key string) interface{} {
+	obj, _ := s.delegate.Get(key)
+	return obj
+}
+
+// GetOrLoad returns the cached value for key, invoking the loader configured
+// via WithSource on a miss.
+func (s *EvictionStore) GetOrLoad(key string) (interface{}, error) {
+	return s.delegate.GetOrLoad(key)
+}
+
+// Delete removes the object stored under key, if any.
+func (s *EvictionStore) Delete(key string) {
+	s.delegate.Delete(key)
+}
+
+// Len returns the number of objects currently held by the store.
+func (s *EvictionStore) Len() int {
+	return s.delegate.Len()
+}
+
+// Keys returns the keys of all objects currently held by the store, in no
+// particular order.
+func (s *EvictionStore) Keys() []string {
+	return s.delegate.Keys()
+}
+
+// List returns all objects currently held by the store, ordered from most to
+// least recently touched.
+func (s *EvictionStore) List() []interface{} {
+	return s.delegate.List()
+}
+
+// Stats returns a snapshot of the store's cache performance counters.
+func (s *EvictionStore) Stats() store.Stats {
+	return s.delegate.Stats()
+}
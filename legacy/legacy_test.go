@@ -0,0 +1,54 @@
+package legacy
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+type itemTest struct {
+	key string
+}
+
+func testKeyFunc(obj interface{}) string {
+	return obj.(*itemTest).key
+}
+
+func TestEvictionStore(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New(testKeyFunc, 10*time.Minute, fakeClock)
+
+	target.Add(&itemTest{key: "1"})
+	if actual := target.Get("1"); actual == nil {
+		t.Fatalf("expected key 1 to be present")
+	}
+
+	fakeClock.Step(11 * time.Minute)
+	target.Add(&itemTest{key: "2"}) // triggers the eviction sweep for key 1
+	if actual := target.Get("1"); actual != nil {
+		t.Fatalf("expected key 1 to have expired, got %v", actual)
+	}
+
+	target.Delete("2")
+	if actual := target.Len(); actual != 0 {
+		t.Fatalf("expected the store to be empty after Delete, got %d", actual)
+	}
+}
+
+func TestEvictionStoreCallbacks(t *testing.T) {
+	fakeClock := &clock.FakeClock{}
+	target := New(testKeyFunc, 10*time.Minute, fakeClock)
+
+	var evictedKeys []string
+	target.OnEviction(func(key string, obj interface{}) {
+		evictedKeys = append(evictedKeys, key)
+	})
+
+	target.Add(&itemTest{key: "1"})
+	target.Delete("1")
+
+	if len(evictedKeys) != 1 || evictedKeys[0] != "1" {
+		t.Fatalf("expected Delete to fire an eviction callback for key 1, got %v", evictedKeys)
+	}
+}